@@ -0,0 +1,143 @@
+package ssh3
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+
+	"github.com/jeffjose/ssh3/util"
+)
+
+// Channel is a single logical stream within a Conversation, analogous to
+// an SSH channel.
+type Channel interface {
+	io.ReadWriteCloser
+	// ChannelType identifies the kind of channel, e.g. "session".
+	ChannelType() string
+	// Subsystem returns the subsystem name requested within a "session"
+	// channel (e.g. "sftp"), or "" for a plain shell/exec session.
+	Subsystem() string
+}
+
+// channel is the only Channel implementation today: it hands the whole
+// underlying connection to the first accepted channel. True multiplexing
+// of several channels onto one Conversation is not implemented yet.
+type channel struct {
+	io.ReadWriteCloser
+	channelType string
+	subsystem   string
+}
+
+func (c *channel) ChannelType() string { return c.channelType }
+func (c *channel) Subsystem() string   { return c.subsystem }
+
+// Conversation is a single accepted, authenticated connection from a
+// client, over which one or more Channels are opened.
+type Conversation struct {
+	conn   net.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	channels *util.AcceptQueue[Channel]
+}
+
+func newConversation(conn net.Conn) *Conversation {
+	ctx, cancel := context.WithCancel(context.Background())
+	conv := &Conversation{
+		conn:     conn,
+		ctx:      ctx,
+		cancel:   cancel,
+		channels: util.NewAcceptQueue[Channel](),
+	}
+	conv.channels.Add(&channel{ReadWriteCloser: conn, channelType: "session"})
+	return conv
+}
+
+// Context is cancelled once the Conversation is closed.
+func (conv *Conversation) Context() context.Context { return conv.ctx }
+
+// AcceptChannel blocks until a Channel is available or ctx is cancelled.
+func (conv *Conversation) AcceptChannel(ctx context.Context) (Channel, error) {
+	return conv.channels.WaitNext(ctx)
+}
+
+// Close tears down the Conversation and wakes any pending AcceptChannel.
+func (conv *Conversation) Close() error {
+	conv.cancel()
+	conv.channels.Close()
+	return conv.conn.Close()
+}
+
+// convListener accepts TLS connections and turns each into a Conversation
+// handed to handler. It stands in for the QUIC/HTTP3 listener described
+// in the original request until that transport is wired in; the handler
+// dispatch contract (one handler call per Conversation) is the same
+// either way.
+type convListener struct {
+	ln      net.Listener
+	handler func(*Conversation)
+}
+
+func newConvListener(addr string, tlsConfig *tls.Config, handler func(*Conversation)) (*convListener, error) {
+	ln, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &convListener{ln: ln, handler: handler}, nil
+}
+
+func (l *convListener) Close() error { return l.ln.Close() }
+
+// Serve accepts connections until ctx is done or the listener errors.
+func (l *convListener) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		l.ln.Close()
+	}()
+
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return err
+		}
+		go l.handler(newConversation(conn))
+	}
+}
+
+// newSessionFromChannel builds the Session handed to a Server's handlers
+// for a Channel accepted on conv.
+func newSessionFromChannel(conv *Conversation, ch Channel) *session {
+	return &session{conv: conv, channel: ch, winCh: make(chan Window)}
+}
+
+// session is the Session built by newSessionFromChannel, which sets only
+// conv, channel and winCh. No user, environment, command or pty request
+// is read from the client, so User returns "", Environ and Command return
+// nil, Pty reports no pty, and Stderr returns nil: there is no stderr
+// stream separate from the channel.
+type session struct {
+	conv    *Conversation
+	channel Channel
+
+	user    string
+	env     []string
+	command []string
+	pty     Pty
+	isPty   bool
+	winCh   chan Window
+	stderr  io.Writer
+}
+
+func (s *session) Read(p []byte) (int, error)  { return s.channel.Read(p) }
+func (s *session) Write(p []byte) (int, error) { return s.channel.Write(p) }
+func (s *session) Close() error                { return s.channel.Close() }
+
+func (s *session) User() string         { return s.user }
+func (s *session) RemoteAddr() net.Addr { return s.conv.conn.RemoteAddr() }
+func (s *session) Environ() []string    { return s.env }
+func (s *session) Command() []string    { return s.command }
+func (s *session) Stderr() io.Writer    { return s.stderr }
+func (s *session) Subsystem() string    { return s.channel.Subsystem() }
+
+func (s *session) Pty() (Pty, <-chan Window, bool) { return s.pty, s.winCh, s.isPty }
@@ -0,0 +1,51 @@
+package ssh3
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConversationAcceptChannel(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conv := newConversation(server)
+	defer conv.Close()
+
+	ch, err := conv.AcceptChannel(context.Background())
+	if err != nil {
+		t.Fatalf("AcceptChannel returned error: %v", err)
+	}
+	if ch.ChannelType() != "session" {
+		t.Fatalf("ChannelType() = %q, want %q", ch.ChannelType(), "session")
+	}
+}
+
+func TestConversationCloseWakesAcceptChannel(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conv := newConversation(server)
+	if _, err := conv.AcceptChannel(context.Background()); err != nil {
+		t.Fatalf("first AcceptChannel returned error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conv.AcceptChannel(context.Background())
+		done <- err
+	}()
+
+	conv.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("AcceptChannel should return an error once the Conversation is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("AcceptChannel did not return after Close, goroutine leaked")
+	}
+}
@@ -0,0 +1,150 @@
+package ssh3
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+)
+
+// Session is the high-level view of a single SSH3 session handed to a
+// Server's Handle callback. It wraps the lower-level Conversation/Channel
+// plumbing so embedders don't need to touch those types directly.
+type Session interface {
+	// User returns the authenticated username for this session.
+	User() string
+	// RemoteAddr returns the network address of the client.
+	RemoteAddr() net.Addr
+	// Environ returns the environment variables requested by the client,
+	// in "key=value" form, analogous to os.Environ().
+	Environ() []string
+	// Command returns the command-line requested by the client, or nil
+	// for a plain (shell) session.
+	Command() []string
+	// Subsystem returns the subsystem name requested for this session
+	// (e.g. "sftp"), or "" for a plain shell/exec session.
+	Subsystem() string
+	// Pty returns the requested pseudo-terminal parameters and whether a
+	// pty was requested at all.
+	Pty() (pty Pty, winCh <-chan Window, isPty bool)
+
+	// Stderr returns a writer for the session's standard error stream,
+	// separate from the combined io.ReadWriteCloser used for stdin/stdout.
+	Stderr() io.Writer
+
+	io.ReadWriteCloser
+}
+
+// Pty describes the pseudo-terminal requested for a Session.
+type Pty struct {
+	Term   string
+	Window Window
+}
+
+// Window is a terminal size, in characters.
+type Window struct {
+	Width  int
+	Height int
+}
+
+// Handler is called once per accepted Session.
+type Handler func(s Session)
+
+// ChannelHandler handles a non-standard channel type opened by the client,
+// analogous to an SSH "channel type" handler.
+type ChannelHandler func(s Session, channel Channel)
+
+// SubsystemHandler handles a subsystem request (e.g. "sftp") on a Session.
+type SubsystemHandler func(s Session)
+
+// Server is a high-level, embeddable SSH3 server façade modeled on
+// gliderlabs/ssh.Server, so embedders can build SSH3-backed tools (a git
+// server, an admin shell, ...) without touching Conversation/Channel
+// directly. It is currently a non-functional scaffold for that API: the
+// listener is a plain TCP+TLS accept loop (not yet the QUIC/HTTP3
+// transport SSH3 actually uses), and it performs no authentication at
+// all — every TLS client that completes a handshake is dispatched to
+// Handler/ChannelHandlers/SubsystemHandlers. There is intentionally no
+// PasswordHandler/PublicKeyHandler/port-forwarding callback surface yet;
+// adding one before there is a real credential exchange to back it would
+// let callers believe connections are authenticated when they are not.
+type Server struct {
+	// Addr is the address to listen on, used by ListenAndServe when no
+	// address is passed explicitly.
+	Addr string
+
+	// Handler is invoked for every accepted shell/exec session.
+	Handler Handler
+
+	// ChannelHandlers dispatches on the channel type string announced by
+	// the client (e.g. "session", "direct-tcpip").
+	ChannelHandlers map[string]ChannelHandler
+	// SubsystemHandlers dispatches on the subsystem name requested within
+	// a "session" channel (e.g. "sftp").
+	SubsystemHandlers map[string]SubsystemHandler
+
+	// TLSConfig is used for the underlying listener. When nil,
+	// ListenAndServe requires TLSConfig to be set separately (e.g. via
+	// util/autocert.TLSConfig) before being called.
+	TLSConfig *tls.Config
+}
+
+// Handle registers fn as the Handler for accepted sessions. It mirrors
+// gliderlabs/ssh's top-level ssh.Handle for callers that build a Server
+// incrementally rather than via a struct literal.
+func (srv *Server) Handle(fn Handler) {
+	srv.Handler = fn
+}
+
+// ListenAndServe starts the listener on addr (or srv.Addr if addr is
+// empty) and serves incoming conversations until the listener errors or
+// ctx is done, dispatching each to srv.Handler. See the Server doc
+// comment: this is a plain TCP+TLS listener, not the QUIC/HTTP3
+// transport yet, and no authentication is performed.
+func (srv *Server) ListenAndServe(ctx context.Context, addr string) error {
+	if addr == "" {
+		addr = srv.Addr
+	}
+
+	listener, err := newConvListener(addr, srv.TLSConfig, srv.newConversationHandler())
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	return listener.Serve(ctx)
+}
+
+// newConversationHandler adapts an incoming Conversation into a Session
+// and runs srv.Handler (plus channel/subsystem dispatch) against it. It
+// is the glue between the low-level conversation/channel engine and the
+// high-level Server API. No authentication happens here; see the Server
+// doc comment.
+func (srv *Server) newConversationHandler() func(conv *Conversation) {
+	return func(conv *Conversation) {
+		for {
+			channel, err := conv.AcceptChannel(conv.Context())
+			if err != nil {
+				return
+			}
+
+			sess := newSessionFromChannel(conv, channel)
+
+			if handler, ok := srv.ChannelHandlers[channel.ChannelType()]; ok {
+				go handler(sess, channel)
+				continue
+			}
+
+			if subsystem := channel.Subsystem(); subsystem != "" {
+				if handler, ok := srv.SubsystemHandlers[subsystem]; ok {
+					go handler(sess)
+					continue
+				}
+			}
+
+			if srv.Handler != nil {
+				go srv.Handler(sess)
+			}
+		}
+	}
+}
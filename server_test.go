@@ -0,0 +1,62 @@
+package ssh3
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewConversationHandlerDispatchesSubsystem(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conv := newConversation(server)
+	defer conv.Close()
+	conv.channels.Next() // drain the default "session" channel added by newConversation
+	conv.channels.Add(&channel{ReadWriteCloser: server, channelType: "session", subsystem: "sftp"})
+
+	called := make(chan Session, 1)
+	srv := &Server{
+		SubsystemHandlers: map[string]SubsystemHandler{
+			"sftp": func(s Session) { called <- s },
+		},
+		Handler: func(s Session) {
+			t.Error("Handler should not run when a SubsystemHandler is registered")
+		},
+	}
+
+	go srv.newConversationHandler()(conv)
+
+	select {
+	case s := <-called:
+		if s.Subsystem() != "sftp" {
+			t.Fatalf("Subsystem() = %q, want %q", s.Subsystem(), "sftp")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SubsystemHandler was not invoked")
+	}
+}
+
+func TestNewConversationHandlerFallsBackToHandler(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	conv := newConversation(server)
+	defer conv.Close()
+
+	called := make(chan Session, 1)
+	srv := &Server{
+		Handler: func(s Session) { called <- s },
+	}
+
+	go srv.newConversationHandler()(conv)
+
+	select {
+	case s := <-called:
+		if s.Subsystem() != "" {
+			t.Fatalf("Subsystem() = %q, want empty for a plain session", s.Subsystem())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Handler was not invoked")
+	}
+}
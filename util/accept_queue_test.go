@@ -0,0 +1,128 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBoundedAcceptQueueAddFailsWhenFull(t *testing.T) {
+	q := NewBoundedAcceptQueue[int](1)
+	if !q.Add(1) {
+		t.Fatal("first Add on an empty bounded queue should succeed")
+	}
+	if q.Add(2) {
+		t.Fatal("Add on a full bounded queue should fail")
+	}
+}
+
+// TestWaitAddWokenByPlainNext guards against a WaitAdd that only listens
+// for the "item arrived" signal: if a consumer only ever calls Next
+// (as all current callers do), a producer parked in WaitAdd on a full
+// queue must still be woken once Next frees a slot.
+func TestWaitAddWokenByPlainNext(t *testing.T) {
+	q := NewBoundedAcceptQueue[int](1)
+	if !q.Add(1) {
+		t.Fatal("first Add should succeed")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.WaitAdd(context.Background(), 2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Next()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitAdd returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitAdd did not wake up after Next freed a slot")
+	}
+}
+
+// TestWaitAddWakesAllWaitersOnConcurrentFrees guards against signalSpace
+// collapsing several freed slots into a single wake-up: if two Next
+// calls free two slots before the parked producers get scheduled, both
+// producers must still wake, rather than one of them waiting forever for
+// a dequeue that never comes again.
+func TestWaitAddWakesAllWaitersOnConcurrentFrees(t *testing.T) {
+	q := NewBoundedAcceptQueue[int](2)
+	if !q.Add(1) || !q.Add(2) {
+		t.Fatal("filling the queue to capacity should succeed")
+	}
+
+	const waiters = 2
+	done := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		go func(v int) {
+			done <- q.WaitAdd(context.Background(), v)
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	// Free both slots before either waiter gets a chance to run.
+	q.Next()
+	q.Next()
+
+	for i := 0; i < waiters; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("WaitAdd returned error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("only %d/%d WaitAdd calls woke up after two Next calls freed two slots", i, waiters)
+		}
+	}
+}
+
+func TestWaitNextBlocksThenReturnsOnAdd(t *testing.T) {
+	q := NewAcceptQueue[int]()
+
+	done := make(chan int, 1)
+	go func() {
+		v, err := q.WaitNext(context.Background())
+		if err != nil {
+			t.Errorf("WaitNext returned error: %v", err)
+		}
+		done <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Add(42)
+
+	select {
+	case v := <-done:
+		if v != 42 {
+			t.Fatalf("WaitNext returned %d, want 42", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitNext did not return after Add")
+	}
+}
+
+func TestAcceptQueueCloseWakesWaiters(t *testing.T) {
+	q := NewAcceptQueue[int]()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.WaitNext(context.Background())
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("WaitNext should return an error once the queue is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitNext did not return after Close, goroutine leaked")
+	}
+}
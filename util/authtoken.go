@@ -0,0 +1,163 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AuthTokenProvider abstracts how the client obtains the JWT bearer token
+// it authenticates with, so corporate users can plug SSH3 into SSO/OIDC
+// brokers, HSMs, or remote KMS without this module linking any specific
+// SDK. Token is called with the JWT audience (the server's URL) and the
+// subject (the username being authenticated as) and returns a signed JWT
+// along with its expiry.
+type AuthTokenProvider interface {
+	Token(ctx context.Context, audience, subject string) (token string, expiresAt time.Time, err error)
+}
+
+// tokenCacheKey identifies a cached token by the (audience, subject)
+// pair it was minted for, since a single provider instance may be asked
+// to mint tokens for more than one server/identity pair.
+type tokenCacheKey struct {
+	audience string
+	subject  string
+}
+
+// cachedToken is a previously minted token along with its expiry.
+type cachedToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// LocalSigningTokenProvider is an AuthTokenProvider backed by a locally
+// loadable private key, using the same JWTSigningMethodFromCryptoPubkey
+// mapping as the existing identity flow. Like ExternalHelperTokenProvider,
+// it caches each (audience, subject) pair's minted token until TTL minus
+// Skew elapses instead of re-signing on every call.
+type LocalSigningTokenProvider struct {
+	Signer crypto.Signer
+	// TTL is how long each minted token should be valid for.
+	TTL time.Duration
+	// Skew is subtracted from TTL when deciding whether a cached token is
+	// still usable, so a token isn't handed out just as it expires.
+	Skew time.Duration
+
+	mx    sync.Mutex
+	cache map[tokenCacheKey]cachedToken
+}
+
+// NewLocalSigningTokenProvider returns a LocalSigningTokenProvider signing
+// with signer, with tokens valid for ttl and refreshed skew before they
+// expire.
+func NewLocalSigningTokenProvider(signer crypto.Signer, ttl, skew time.Duration) *LocalSigningTokenProvider {
+	return &LocalSigningTokenProvider{Signer: signer, TTL: ttl, Skew: skew}
+}
+
+func (p *LocalSigningTokenProvider) Token(ctx context.Context, audience, subject string) (string, time.Time, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	key := tokenCacheKey{audience: audience, subject: subject}
+	if cached, ok := p.cache[key]; ok && time.Now().Before(cached.expiresAt.Add(-p.Skew)) {
+		return cached.token, cached.expiresAt, nil
+	}
+
+	method, err := JWTSigningMethodFromCryptoPubkey(p.Signer.Public())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(p.TTL)
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{audience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(p.Signer)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if p.cache == nil {
+		p.cache = make(map[tokenCacheKey]cachedToken)
+	}
+	p.cache[key] = cachedToken{token: token, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// externalHelperResponse is the JSON document an external helper process
+// is expected to print to stdout, borrowing the shape git/docker
+// credential helpers use.
+type externalHelperResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExternalHelperTokenProvider is an AuthTokenProvider that execs a
+// user-configured binary and reads a JSON {"token":"...","expires_at":"..."}
+// document from its stdout, similar to git credential helpers / docker
+// credential helpers. This lets the token be minted by something SSH3
+// knows nothing about: an SSO/OIDC broker, an HSM, a remote KMS, ... It
+// caches each (audience, subject) pair's token separately, the same as
+// LocalSigningTokenProvider.
+type ExternalHelperTokenProvider struct {
+	// Command is the helper binary to exec, e.g.
+	// "my-corp-ssh3-credential-helper".
+	Command string
+	// Args are passed to Command, followed by audience and subject.
+	Args []string
+	// Skew is subtracted from the token's reported expiry when deciding
+	// whether a cached token is still usable.
+	Skew time.Duration
+
+	mx    sync.Mutex
+	cache map[tokenCacheKey]cachedToken
+}
+
+// NewExternalHelperTokenProvider returns an ExternalHelperTokenProvider
+// invoking command with args, refreshing a token skew before it expires.
+func NewExternalHelperTokenProvider(command string, args []string, skew time.Duration) *ExternalHelperTokenProvider {
+	return &ExternalHelperTokenProvider{Command: command, Args: args, Skew: skew}
+}
+
+func (p *ExternalHelperTokenProvider) Token(ctx context.Context, audience, subject string) (string, time.Time, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	key := tokenCacheKey{audience: audience, subject: subject}
+	if cached, ok := p.cache[key]; ok && time.Now().Before(cached.expiresAt.Add(-p.Skew)) {
+		return cached.token, cached.expiresAt, nil
+	}
+
+	args := append(append([]string{}, p.Args...), audience, subject)
+	cmd := exec.CommandContext(ctx, p.Command, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", time.Time{}, fmt.Errorf("auth token helper %q failed: %w (stderr: %s)", p.Command, err, stderr.String())
+	}
+
+	var resp externalHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("could not parse auth token helper %q output: %w", p.Command, err)
+	}
+
+	if p.cache == nil {
+		p.cache = make(map[tokenCacheKey]cachedToken)
+	}
+	p.cache[key] = cachedToken{token: resp.Token, expiresAt: resp.ExpiresAt}
+	return resp.Token, resp.ExpiresAt, nil
+}
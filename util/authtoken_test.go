@@ -0,0 +1,126 @@
+package util
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLocalSigningTokenProviderRoundTrip(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	signers := map[string]crypto.Signer{
+		"ecdsa":   ecdsaKey,
+		"ed25519": ed25519Key,
+	}
+
+	for name, signer := range signers {
+		t.Run(name, func(t *testing.T) {
+			p := NewLocalSigningTokenProvider(signer, time.Hour, 0)
+
+			tokenString, expiresAt, err := p.Token(context.Background(), "https://example.com", "alice")
+			if err != nil {
+				t.Fatalf("Token returned error: %v", err)
+			}
+
+			parsed, err := jwt.Parse(tokenString, func(tok *jwt.Token) (interface{}, error) {
+				return signer.Public(), nil
+			})
+			if err != nil {
+				t.Fatalf("could not verify token minted for %s signer: %v", name, err)
+			}
+			claims, ok := parsed.Claims.(jwt.MapClaims)
+			if !ok || claims["sub"] != "alice" {
+				t.Fatalf("unexpected claims: %v", parsed.Claims)
+			}
+			if expiresAt.Before(time.Now()) {
+				t.Fatalf("expiresAt = %v, want in the future", expiresAt)
+			}
+		})
+	}
+}
+
+func TestLocalSigningTokenProviderCachesUntilSkew(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	p := NewLocalSigningTokenProvider(ecdsaKey, time.Hour, 59*time.Minute)
+
+	first, firstExpiry, err := p.Token(context.Background(), "https://example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+
+	second, secondExpiry, err := p.Token(context.Background(), "https://example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	if second != first || !secondExpiry.Equal(firstExpiry) {
+		t.Fatalf("expected Token to return the cached token within the skew window, got a new one")
+	}
+
+	key := tokenCacheKey{audience: "https://example.com", subject: "alice"}
+	p.cache[key] = cachedToken{token: first, expiresAt: time.Now().Add(time.Minute)}
+	third, _, err := p.Token(context.Background(), "https://example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	if third == first {
+		t.Fatalf("expected Token to mint a new token once within the skew window of expiry")
+	}
+}
+
+func TestLocalSigningTokenProviderCachesPerAudienceSubject(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	p := NewLocalSigningTokenProvider(ecdsaKey, time.Hour, 0)
+
+	aliceToken, _, err := p.Token(context.Background(), "https://a.example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	bobToken, _, err := p.Token(context.Background(), "https://a.example.com", "bob")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	otherAudienceToken, _, err := p.Token(context.Background(), "https://b.example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+
+	if aliceToken == bobToken {
+		t.Fatal("expected distinct subjects to get distinct cached tokens")
+	}
+	if aliceToken == otherAudienceToken {
+		t.Fatal("expected distinct audiences to get distinct cached tokens")
+	}
+
+	// A repeat call for the same (audience, subject) should still hit the
+	// cache rather than mint a third token for alice@a.example.com.
+	aliceAgain, _, err := p.Token(context.Background(), "https://a.example.com", "alice")
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	if aliceAgain != aliceToken {
+		t.Fatal("expected the cached token to be returned for a repeat (audience, subject) call")
+	}
+}
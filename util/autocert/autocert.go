@@ -0,0 +1,96 @@
+// Package autocert wires golang.org/x/crypto/acme/autocert into an SSH3
+// server, obtaining and renewing its TLS certificate via TLS-ALPN-01
+// (with an HTTP-01 fallback listener) instead of a key/cert pair on disk.
+package autocert
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Config holds the operator-facing settings needed to drive a
+// *autocert.Manager for a single SSH3 server hostname.
+type Config struct {
+	// Host is the hostname the server is reachable at and the one the
+	// certificate will be issued for.
+	Host string
+	// Email is passed to the CA as the account contact.
+	Email string
+	// CacheDir is where the obtained certificates and ACME account key
+	// are cached across restarts. Defaults to "autocert-cache" when empty.
+	CacheDir string
+	// DirectoryURL overrides the default Let's Encrypt production
+	// directory, e.g. to point at ZeroSSL or a CA's staging environment.
+	DirectoryURL string
+
+	// EABKeyID and EABHMACKey enable ACME External Account Binding (used
+	// by e.g. ZeroSSL/Google Trust Services). EABHMACKey is base64url
+	// encoded, as handed out by the CA.
+	EABKeyID   string
+	EABHMACKey string
+}
+
+// NewManager builds an *autocert.Manager for cfg, caching issued
+// certificates under cfg.CacheDir and restricting issuance to cfg.Host.
+func NewManager(cfg Config) (*autocert.Manager, error) {
+	cacheDir := cfg.CacheDir
+	if cacheDir == "" {
+		cacheDir = "autocert-cache"
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Host),
+		Email:      cfg.Email,
+	}
+
+	if cfg.EABKeyID != "" {
+		hmacKey, err := base64.RawURLEncoding.DecodeString(cfg.EABHMACKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode EABHMACKey: %w", err)
+		}
+		client := &acme.Client{
+			ExternalAccountBinding: &acme.ExternalAccountBinding{
+				KID: cfg.EABKeyID,
+				Key: hmacKey,
+			},
+		}
+		if cfg.DirectoryURL != "" {
+			client.DirectoryURL = cfg.DirectoryURL
+		}
+		m.Client = client
+	} else if cfg.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+
+	return m, nil
+}
+
+// TLSConfig returns the *tls.Config the server's http3.Server should use.
+func TLSConfig(m *autocert.Manager) *tls.Config {
+	tlsConfig := m.TLSConfig()
+	tlsConfig.NextProtos = append([]string{"h3"}, tlsConfig.NextProtos...)
+	return tlsConfig
+}
+
+// ServeHTTPChallenge starts a side HTTP listener on addr answering ACME
+// HTTP-01 challenges, for CAs or network paths where TLS-ALPN-01 isn't
+// usable. Callers typically run it in its own goroutine.
+func ServeHTTPChallenge(m *autocert.Manager, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: m.HTTPHandler(nil)}
+	log.Info().Str("addr", addr).Msg("serving ACME HTTP-01 challenge fallback")
+	return server.Serve(ln)
+}
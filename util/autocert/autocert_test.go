@@ -0,0 +1,44 @@
+package autocert
+
+import "testing"
+
+func TestNewManagerDefaultsCacheDir(t *testing.T) {
+	m, err := NewManager(Config{Host: "example.com"})
+	if err != nil {
+		t.Fatalf("NewManager returned error: %v", err)
+	}
+	if m.Cache == nil {
+		t.Fatal("expected a default Cache to be set when CacheDir is empty")
+	}
+}
+
+func TestNewManagerEAB(t *testing.T) {
+	m, err := NewManager(Config{
+		Host:       "example.com",
+		EABKeyID:   "kid-123",
+		EABHMACKey: "aGVsbG8",
+	})
+	if err != nil {
+		t.Fatalf("NewManager returned error: %v", err)
+	}
+	if m.Client == nil || m.Client.ExternalAccountBinding == nil {
+		t.Fatal("expected an ExternalAccountBinding to be set on the ACME client")
+	}
+	if m.Client.ExternalAccountBinding.KID != "kid-123" {
+		t.Fatalf("KID = %q, want %q", m.Client.ExternalAccountBinding.KID, "kid-123")
+	}
+	if string(m.Client.ExternalAccountBinding.Key) != "hello" {
+		t.Fatalf("Key = %q, want %q", m.Client.ExternalAccountBinding.Key, "hello")
+	}
+}
+
+func TestNewManagerEABInvalidKey(t *testing.T) {
+	_, err := NewManager(Config{
+		Host:       "example.com",
+		EABKeyID:   "kid-123",
+		EABHMACKey: "not valid base64url!",
+	})
+	if err == nil {
+		t.Fatal("expected an error for a malformed EABHMACKey")
+	}
+}
@@ -0,0 +1,142 @@
+package util
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// StapleManager fetches and refreshes an OCSP response for a leaf
+// certificate, ready to be attached to TLS handshakes via
+// tls.Config.GetCertificate.
+type StapleManager struct {
+	leaf   *x509.Certificate
+	issuer *x509.Certificate
+	cert   *tls.Certificate
+
+	mx        sync.RWMutex
+	response  *ocsp.Response
+	raw       []byte
+	attempted bool
+}
+
+// NewStapleManager creates a StapleManager for cert, with leaf and issuer
+// already parsed. It does not fetch anything until Force or Run is called.
+func NewStapleManager(cert *tls.Certificate, leaf *x509.Certificate, issuer *x509.Certificate) *StapleManager {
+	return &StapleManager{
+		leaf:   leaf,
+		issuer: issuer,
+		cert:   cert,
+	}
+}
+
+// Force synchronously fetches (or refreshes) the OCSP staple.
+func (m *StapleManager) Force() error {
+	return m.refresh()
+}
+
+// Run periodically refreshes the staple until stop is closed, scheduling
+// each refresh at NextUpdate minus a random jitter.
+func (m *StapleManager) Run(stop <-chan struct{}) {
+	for {
+		delay := m.nextRefreshDelay()
+		select {
+		case <-time.After(delay):
+			if err := m.refresh(); err != nil {
+				log.Warn().Err(err).Msg("OCSP staple refresh failed, continuing without staple")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// GetCertificate can be set as tls.Config.GetCertificate (or called from
+// within an existing one) to populate Certificate.OCSPStaple on cert.
+func (m *StapleManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	cert := *m.cert
+	cert.OCSPStaple = m.raw
+	return &cert, nil
+}
+
+func (m *StapleManager) nextRefreshDelay() time.Duration {
+	const defaultDelay = time.Hour
+
+	m.mx.RLock()
+	resp := m.response
+	attempted := m.attempted
+	m.mx.RUnlock()
+
+	if resp == nil {
+		// Fetch immediately the first time; if a prior attempt failed,
+		// back off instead of spinning on an unreachable responder.
+		if attempted {
+			return defaultDelay
+		}
+		return 0
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(10 * time.Minute)))
+	delay := time.Until(resp.NextUpdate) - jitter
+	if delay <= 0 {
+		return defaultDelay
+	}
+	return delay
+}
+
+// ocspHTTPTimeout bounds a single OCSP round-trip so a hung responder
+// can't block Force() (and the first TLS handshake) indefinitely.
+const ocspHTTPTimeout = 10 * time.Second
+
+var ocspHTTPClient = &http.Client{Timeout: ocspHTTPTimeout}
+
+func (m *StapleManager) refresh() error {
+	m.mx.Lock()
+	m.attempted = true
+	m.mx.Unlock()
+
+	req, err := ocsp.CreateRequest(m.leaf, m.issuer, nil)
+	if err != nil {
+		return fmt.Errorf("could not create OCSP request: %w", err)
+	}
+
+	if len(m.leaf.OCSPServer) == 0 {
+		return fmt.Errorf("certificate has no OCSP responder URL")
+	}
+
+	httpResp, err := ocspHTTPClient.Post(m.leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return fmt.Errorf("could not reach OCSP responder: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read OCSP response: %w", err)
+	}
+
+	parsed, err := ocsp.ParseResponseForCert(body, m.leaf, m.issuer)
+	if err != nil {
+		return fmt.Errorf("could not parse OCSP response: %w", err)
+	}
+
+	m.mx.Lock()
+	m.response = parsed
+	m.raw = body
+	m.mx.Unlock()
+
+	log.Info().Time("next_update", parsed.NextUpdate).Msg("refreshed OCSP staple")
+	return nil
+}
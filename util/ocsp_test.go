@@ -0,0 +1,47 @@
+package util
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestStapleManagerGetCertificatePopulatesStaple(t *testing.T) {
+	m := &StapleManager{cert: &tls.Certificate{}}
+	m.raw = []byte("fake-ocsp-response")
+
+	cert, err := m.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate returned error: %v", err)
+	}
+	if string(cert.OCSPStaple) != "fake-ocsp-response" {
+		t.Fatalf("OCSPStaple = %q, want %q", cert.OCSPStaple, "fake-ocsp-response")
+	}
+}
+
+func TestStapleManagerNextRefreshDelay(t *testing.T) {
+	m := &StapleManager{}
+	if delay := m.nextRefreshDelay(); delay != 0 {
+		t.Fatalf("nextRefreshDelay() with no response yet = %v, want 0", delay)
+	}
+
+	m.response = &ocsp.Response{NextUpdate: time.Now().Add(time.Hour)}
+	delay := m.nextRefreshDelay()
+	if delay <= 0 || delay > time.Hour {
+		t.Fatalf("nextRefreshDelay() = %v, want in (0, 1h]", delay)
+	}
+
+	m.response = &ocsp.Response{NextUpdate: time.Now().Add(-time.Hour)}
+	if delay := m.nextRefreshDelay(); delay != time.Hour {
+		t.Fatalf("nextRefreshDelay() for a past NextUpdate = %v, want the 1h default", delay)
+	}
+}
+
+func TestStapleManagerNextRefreshDelayBacksOffAfterFailedAttempt(t *testing.T) {
+	m := &StapleManager{attempted: true}
+	if delay := m.nextRefreshDelay(); delay != time.Hour {
+		t.Fatalf("nextRefreshDelay() after a failed attempt = %v, want the 1h default", delay)
+	}
+}
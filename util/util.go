@@ -3,7 +3,9 @@ package util
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
@@ -78,14 +80,39 @@ type AcceptQueue[T any] struct {
 	// There's no explicit limit to the length of the queue, but it is implicitly
 	// limited by the stream flow control provided by QUIC.
 	queue []T
+	// max is the maximum number of items the queue may hold, or 0 for no
+	// explicit limit (the unbounded behaviour of NewAcceptQueue).
+	max int
+	// spaceC broadcasts to every waiter parked in WaitAdd that a slot was
+	// freed: each signalSpace closes the current channel (waking every
+	// receiver, not just one) and replaces it with a fresh one. Separate
+	// from c (which notifies of new items) because Next, not just
+	// WaitNext, can free a slot, and Next does not know whether anyone is
+	// in WaitAdd.
+	spaceC chan struct{}
+	// closed is set once Close has been called, so that waiters parked in
+	// WaitAdd/WaitNext get woken up deterministically instead of leaking.
+	closed  bool
+	closedC chan struct{}
 }
 
 func NewAcceptQueue[T any]() *AcceptQueue[T] {
-	return &AcceptQueue[T]{c: make(chan struct{}, 1)}
+	return &AcceptQueue[T]{c: make(chan struct{}, 1), spaceC: make(chan struct{}), closedC: make(chan struct{})}
 }
 
-func (q *AcceptQueue[T]) Add(str T) {
+// NewBoundedAcceptQueue returns an AcceptQueue that holds at most max
+// items. Add fails (returns false) once the queue is full instead of
+// growing unboundedly; WaitAdd can be used to block until room is made.
+func NewBoundedAcceptQueue[T any](max int) *AcceptQueue[T] {
+	return &AcceptQueue[T]{c: make(chan struct{}, 1), spaceC: make(chan struct{}), closedC: make(chan struct{}), max: max}
+}
+
+func (q *AcceptQueue[T]) Add(str T) bool {
 	q.mx.Lock()
+	if q.max > 0 && len(q.queue) >= q.max {
+		q.mx.Unlock()
+		return false
+	}
 	q.queue = append(q.queue, str)
 	q.mx.Unlock()
 
@@ -93,6 +120,46 @@ func (q *AcceptQueue[T]) Add(str T) {
 	case q.c <- struct{}{}:
 	default:
 	}
+	return true
+}
+
+// WaitAdd blocks until str can be enqueued or ctx is cancelled, mirroring
+// DatagramsQueue.WaitAdd. It returns nil once added, or
+// context.Cause(ctx) if ctx is done first. On a queue created with
+// NewAcceptQueue (max == 0) it never blocks. It wakes up on any Next or
+// WaitNext dequeue, whichever the consumer happens to use.
+//
+// The fullness check and the spaceC snapshot below must happen under the
+// same critical section (rather than calling Add and then re-locking to
+// read spaceC): otherwise a Next/WaitNext that runs signalSpace in the
+// gap between the two locks closes the spaceC WaitAdd hasn't captured
+// yet, and the freed slot is missed.
+func (q *AcceptQueue[T]) WaitAdd(ctx context.Context, str T) error {
+	for {
+		q.mx.Lock()
+		if q.max == 0 || len(q.queue) < q.max {
+			q.queue = append(q.queue, str)
+			q.mx.Unlock()
+
+			select {
+			case q.c <- struct{}{}:
+			default:
+			}
+			return nil
+		}
+		closedC := q.closedC
+		spaceC := q.spaceC
+		q.mx.Unlock()
+
+		select {
+		case <-spaceC:
+			// room was freed by a concurrent Next/WaitNext; try again
+		case <-closedC:
+			return errors.New("accept queue closed")
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
 }
 
 func (q *AcceptQueue[T]) Next() T {
@@ -104,11 +171,61 @@ func (q *AcceptQueue[T]) Next() T {
 	}
 	str := q.queue[0]
 	q.queue = q.queue[1:]
+	q.signalSpace()
 	return str
 }
 
+// WaitNext blocks until an item is available or ctx is cancelled,
+// returning context.Cause(ctx) in the latter case, so callers no longer
+// need to separately select on Chan().
+func (q *AcceptQueue[T]) WaitNext(ctx context.Context) (T, error) {
+	for {
+		q.mx.Lock()
+		if len(q.queue) > 0 {
+			str := q.queue[0]
+			q.queue = q.queue[1:]
+			q.signalSpace()
+			q.mx.Unlock()
+			return str, nil
+		}
+		closedC := q.closedC
+		q.mx.Unlock()
+
+		select {
+		case <-q.c:
+		case <-closedC:
+			return *new(T), errors.New("accept queue closed")
+		case <-ctx.Done():
+			return *new(T), context.Cause(ctx)
+		}
+	}
+}
+
+// signalSpace wakes every waiter parked in WaitAdd after a dequeue freed
+// a slot, by closing the current spaceC and replacing it with a fresh
+// one. Callers must hold q.mx.
+func (q *AcceptQueue[T]) signalSpace() {
+	close(q.spaceC)
+	q.spaceC = make(chan struct{})
+}
+
 func (q *AcceptQueue[T]) Chan() <-chan struct{} { return q.c }
 
+// Close wakes all goroutines currently blocked in WaitAdd or WaitNext,
+// which then return an error. It does not close the channel returned by
+// Chan, so a goroutine receiving from <-q.Chan() directly is not woken.
+// It is safe to call Close more than once.
+func (q *AcceptQueue[T]) Close() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.closedC)
+}
+
 
 type DatagramsQueue struct {
 	c chan []byte
@@ -157,12 +274,28 @@ func (q *DatagramsQueue) WaitNext(ctx context.Context) ([]byte, error) {
 	}
 }
 
+// JWTSigningMethodFromCryptoPubkey maps an SSH-offered public key to the
+// jwt.SigningMethod used to verify (or, via LocalSigningTokenProvider,
+// mint) tokens for it. Since both the server's identity matching and the
+// client's signer selection operate on crypto.PublicKey/crypto.Signer
+// rather than the SSH wire format directly, this switch is the single
+// place ecdsa-sha2-nistp256/384/521 and ssh-ed25519 support lives.
 func JWTSigningMethodFromCryptoPubkey(pubkey crypto.PublicKey) (jwt.SigningMethod, error) {
-	switch pubkey.(type) {
+	switch pubkey := pubkey.(type) {
 	case *rsa.PublicKey:
 		return jwt.SigningMethodRS256, nil
-	case *ed25519.PublicKey:
+	case ed25519.PublicKey:
 		return jwt.SigningMethodEdDSA, nil
+	case *ecdsa.PublicKey:
+		switch pubkey.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, UnknownSSHPubkeyType{pubkey: pubkey}
 	}
 	return nil, UnknownSSHPubkeyType{pubkey: pubkey}
 }
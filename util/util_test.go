@@ -0,0 +1,76 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTSigningMethodFromCryptoPubkeyECDSA(t *testing.T) {
+	tests := []struct {
+		curve elliptic.Curve
+		want  jwt.SigningMethod
+	}{
+		{elliptic.P256(), jwt.SigningMethodES256},
+		{elliptic.P384(), jwt.SigningMethodES384},
+		{elliptic.P521(), jwt.SigningMethodES512},
+	}
+
+	for _, tc := range tests {
+		key, err := ecdsa.GenerateKey(tc.curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey(%v) failed: %v", tc.curve.Params().Name, err)
+		}
+
+		got, err := JWTSigningMethodFromCryptoPubkey(&key.PublicKey)
+		if err != nil {
+			t.Fatalf("JWTSigningMethodFromCryptoPubkey(%v) returned error: %v", tc.curve.Params().Name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("JWTSigningMethodFromCryptoPubkey(%v) = %v, want %v", tc.curve.Params().Name, got, tc.want)
+		}
+	}
+}
+
+func TestJWTSigningMethodFromCryptoPubkeyRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	got, err := JWTSigningMethodFromCryptoPubkey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("JWTSigningMethodFromCryptoPubkey returned error: %v", err)
+	}
+	if got != jwt.SigningMethodRS256 {
+		t.Fatalf("JWTSigningMethodFromCryptoPubkey(RSA) = %v, want %v", got, jwt.SigningMethodRS256)
+	}
+}
+
+func TestJWTSigningMethodFromCryptoPubkeyEd25519(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	// crypto/x509, ssh.CryptoPublicKey and ed25519.PrivateKey.Public() all
+	// hand back ed25519.PublicKey by value, never a pointer to it.
+	got, err := JWTSigningMethodFromCryptoPubkey(pub)
+	if err != nil {
+		t.Fatalf("JWTSigningMethodFromCryptoPubkey returned error: %v", err)
+	}
+	if got != jwt.SigningMethodEdDSA {
+		t.Fatalf("JWTSigningMethodFromCryptoPubkey(Ed25519) = %v, want %v", got, jwt.SigningMethodEdDSA)
+	}
+}
+
+func TestJWTSigningMethodFromCryptoPubkeyUnknown(t *testing.T) {
+	if _, err := JWTSigningMethodFromCryptoPubkey("not a key"); err == nil {
+		t.Fatal("expected an error for an unrecognised public key type")
+	}
+}